@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	dnstap "github.com/dnstap/golang-dnstap"
+	"github.com/miekg/dns"
+)
+
+// FlatDnstapECS flattens a dnstap message into a map conforming to the
+// Elastic Common Schema's dns.*, source.*, destination.*, network.*,
+// event.* and host.* namespaces, so it can be shipped straight into
+// Elasticsearch/Filebeat/OpenSearch pipelines without transformation.
+func FlatDnstapECS(dt *dnstap.Dnstap, opts FlatOptions) (map[string]interface{}, error) {
+	ipv4Mask := opts.IPv4Mask
+	ipv6Mask := opts.IPv6Mask
+
+	var dnsMessage []byte
+	data := make(map[string]interface{})
+	msg := dt.GetMessage()
+	if msg.GetQueryMessage() != nil {
+		dnsMessage = msg.GetQueryMessage()
+	} else {
+		dnsMessage = msg.GetResponseMessage()
+	}
+
+	dnsMsg := dns.Msg{}
+	if err := dnsMsg.Unpack(dnsMessage); err != nil {
+		return nil, errors.Wrapf(err, "can't parse dns message() failed: %s\n", err)
+	}
+
+	source := map[string]interface{}{
+		"ip":   maskAddress(msg.GetQueryAddress(), ipv4Mask, ipv6Mask),
+		"port": msg.GetQueryPort(),
+	}
+	destination := map[string]interface{}{
+		"ip":   maskAddress(msg.GetResponseAddress(), ipv4Mask, ipv6Mask),
+		"port": msg.GetResponsePort(),
+	}
+	switch msg.GetType() {
+	case dnstap.Message_AUTH_RESPONSE, dnstap.Message_RESOLVER_RESPONSE,
+		dnstap.Message_CLIENT_RESPONSE, dnstap.Message_FORWARDER_RESPONSE,
+		dnstap.Message_STUB_RESPONSE, dnstap.Message_TOOL_RESPONSE:
+		source, destination = destination, source
+	}
+	data["source"] = source
+	data["destination"] = destination
+
+	data["network"] = map[string]interface{}{
+		"transport": msg.GetSocketProtocol().String(),
+		"type":      msg.GetSocketFamily().String(),
+	}
+
+	identity := dt.GetIdentity()
+	hostName := hostname
+	if len(identity) > 0 {
+		hostName = string(identity)
+	}
+	data["host"] = map[string]interface{}{"hostname": hostName}
+
+	question := map[string]interface{}{}
+	if len(dnsMsg.Question) > 0 {
+		qname := dnsMsg.Question[0].Name
+		publicSuffix, registeredDomain, subdomain := DefaultNameDecomposer.Decompose(qname)
+		question["name"] = qname
+		question["type"] = dns.TypeToString[dnsMsg.Question[0].Qtype]
+		question["class"] = dns.ClassToString[dnsMsg.Question[0].Qclass]
+		question["registered_domain"] = registeredDomain
+		question["top_level_domain"] = publicSuffix
+		question["subdomain"] = subdomain
+	}
+
+	dnsData := map[string]interface{}{
+		"question":      question,
+		"response_code": dns.RcodeToString[dnsMsg.Rcode],
+		"header_flags":  headerFlags(&dnsMsg),
+		"id":            strconv.Itoa(int(dnsMsg.MsgHdr.Id)),
+	}
+	if opts.IncludeResourceRecords {
+		dnsData["answers"] = flattenECSAnswers(dnsMsg.Answer, ipv4Mask, ipv6Mask)
+	}
+	data["dns"] = dnsData
+
+	hasQueryTime := msg.GetQueryTimeSec() != 0
+	hasResponseTime := msg.GetResponseTimeSec() != 0
+	event := map[string]interface{}{}
+	if hasQueryTime {
+		event["start"] = time.Unix(int64(msg.GetQueryTimeSec()), int64(msg.GetQueryTimeNsec())).Format(time.RFC3339Nano)
+	}
+	if hasResponseTime {
+		event["end"] = time.Unix(int64(msg.GetResponseTimeSec()), int64(msg.GetResponseTimeNsec())).Format(time.RFC3339Nano)
+	}
+	if hasQueryTime && hasResponseTime {
+		queryTime := time.Unix(int64(msg.GetQueryTimeSec()), int64(msg.GetQueryTimeNsec()))
+		responseTime := time.Unix(int64(msg.GetResponseTimeSec()), int64(msg.GetResponseTimeNsec()))
+		event["duration"] = responseTime.Sub(queryTime).Nanoseconds()
+	}
+	data["event"] = event
+
+	return data, nil
+}
+
+// flattenECSAnswers flattens a message's answer section into ECS's
+// dns.answers[] shape: {name, type, class, ttl, data}.
+func flattenECSAnswers(rrs []dns.RR, ipv4Mask, ipv6Mask net.IPMask) []map[string]interface{} {
+	answers := make([]map[string]interface{}, 0, len(rrs))
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		answers = append(answers, map[string]interface{}{
+			"name":  hdr.Name,
+			"type":  dns.TypeToString[hdr.Rrtype],
+			"class": dns.ClassToString[hdr.Class],
+			"ttl":   hdr.Ttl,
+			"data":  flattenRdataString(rr, ipv4Mask, ipv6Mask),
+		})
+	}
+	return answers
+}
+
+// headerFlags returns the set DNS header flags as ECS's dns.header_flags
+// string array (e.g. ["RD", "RA"]).
+func headerFlags(msg *dns.Msg) []string {
+	var flags []string
+	if msg.Authoritative {
+		flags = append(flags, "AA")
+	}
+	if msg.Truncated {
+		flags = append(flags, "TC")
+	}
+	if msg.RecursionDesired {
+		flags = append(flags, "RD")
+	}
+	if msg.RecursionAvailable {
+		flags = append(flags, "RA")
+	}
+	if msg.AuthenticatedData {
+		flags = append(flags, "AD")
+	}
+	if msg.CheckingDisabled {
+		flags = append(flags, "CD")
+	}
+	return flags
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"encoding/hex"
+	"net"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/miekg/dns"
+)
+
+// flattenEDNS0 extracts the OPT pseudo-RR of a DNS message into a generic
+// map, decoding the options dtap cares about. Options of unknown code
+// (EDNS0_LOCAL) get a hex dump of their data; other option types get
+// their presentation string as "description". It returns nil if opt is nil.
+func flattenEDNS0(opt *dns.OPT, ipv4Mask net.IPMask, ipv6Mask net.IPMask) map[string]interface{} {
+	if opt == nil {
+		return nil
+	}
+
+	edns := make(map[string]interface{})
+	edns["version"] = opt.Version()
+	edns["udp_size"] = opt.UDPSize()
+	edns["do"] = opt.Do()
+	edns["extended_rcode"] = opt.ExtendedRcode()
+
+	options := make([]map[string]interface{}, 0, len(opt.Option))
+	for _, o := range opt.Option {
+		switch v := o.(type) {
+		case *dns.EDNS0_SUBNET:
+			address := v.Address
+			if v.Family == 1 {
+				address = address.Mask(ipv4Mask)
+			} else if v.Family == 2 {
+				address = address.Mask(ipv6Mask)
+			}
+			options = append(options, map[string]interface{}{
+				"code":           "SUBNET",
+				"family":         v.Family,
+				"source_netmask": v.SourceNetmask,
+				"scope_netmask":  v.SourceScope,
+				"address":        address.String(),
+			})
+		case *dns.EDNS0_COOKIE:
+			client, server := splitEDNS0Cookie(v.Cookie)
+			options = append(options, map[string]interface{}{
+				"code":          "COOKIE",
+				"client_cookie": client,
+				"server_cookie": server,
+			})
+		case *dns.EDNS0_NSID:
+			nsid := map[string]interface{}{
+				"code": "NSID",
+				"hex":  v.Nsid,
+			}
+			if raw, err := hex.DecodeString(v.Nsid); err == nil && utf8.Valid(raw) {
+				nsid["text"] = string(raw)
+			}
+			options = append(options, nsid)
+		case *dns.EDNS0_PADDING:
+			options = append(options, map[string]interface{}{
+				"code":   "PADDING",
+				"length": len(v.Padding),
+			})
+		case *dns.EDNS0_TCP_KEEPALIVE:
+			option := map[string]interface{}{
+				"code": "TCP_KEEPALIVE",
+			}
+			if v.Timeout > 0 {
+				option["timeout"] = v.Timeout
+			}
+			options = append(options, option)
+		case *dns.EDNS0_EDE:
+			edns["ede_code"] = v.InfoCode
+			options = append(options, map[string]interface{}{
+				"code":       "EDE",
+				"info_code":  v.InfoCode,
+				"extra_text": v.ExtraText,
+			})
+		case *dns.EDNS0_LOCAL:
+			options = append(options, map[string]interface{}{
+				"code":       "LOCAL",
+				"local_code": v.Code,
+				"data_hex":   hex.EncodeToString(v.Data),
+			})
+		default:
+			// No public API exposes the raw wire bytes for these option
+			// types (UL, LLQ, DAU, DHU, N3U, EXPIRE, ESU, ...), only their
+			// formatted presentation string, so "description" rather than
+			// a misleading "data_hex" field.
+			options = append(options, map[string]interface{}{
+				"code":        strconv.Itoa(int(o.Option())),
+				"description": o.String(),
+			})
+		}
+	}
+	edns["options"] = options
+
+	return edns
+}
+
+// splitEDNS0Cookie splits a raw EDNS0 COOKIE option into its mandatory
+// 8-byte client half and optional 8-24 byte server half, both hex encoded.
+func splitEDNS0Cookie(cookie string) (client string, server string) {
+	raw, err := hex.DecodeString(cookie)
+	if err != nil {
+		return cookie, ""
+	}
+	if len(raw) <= 8 {
+		return hex.EncodeToString(raw), ""
+	}
+	return hex.EncodeToString(raw[:8]), hex.EncodeToString(raw[8:])
+}
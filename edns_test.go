@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestSplitEDNS0Cookie(t *testing.T) {
+	tests := []struct {
+		cookie string
+		client string
+		server string
+	}{
+		{"0102030405060708", "0102030405060708", ""},
+		{"01020304050607080910111213141516", "0102030405060708", "0910111213141516"},
+		{"not-hex", "not-hex", ""},
+	}
+
+	for _, tt := range tests {
+		client, server := splitEDNS0Cookie(tt.cookie)
+		if client != tt.client || server != tt.server {
+			t.Errorf("splitEDNS0Cookie(%q) = (%q, %q), want (%q, %q)", tt.cookie, client, server, tt.client, tt.server)
+		}
+	}
+}
+
+func TestFlattenEDNS0Nil(t *testing.T) {
+	if got := flattenEDNS0(nil, nil, nil); got != nil {
+		t.Errorf("flattenEDNS0(nil, ...) = %v, want nil", got)
+	}
+}
+
+func TestFlattenEDNS0Subnet(t *testing.T) {
+	ipv4Mask := net.CIDRMask(24, 32)
+	opt := &dns.OPT{}
+	opt.Hdr.Name = "."
+	opt.SetUDPSize(4096)
+	opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+		Family:        1,
+		SourceNetmask: 24,
+		Address:       net.ParseIP("192.0.2.55"),
+	})
+
+	edns := flattenEDNS0(opt, ipv4Mask, nil)
+	options, ok := edns["options"].([]map[string]interface{})
+	if !ok || len(options) != 1 {
+		t.Fatalf("flattenEDNS0 options = %#v, want one SUBNET option", edns["options"])
+	}
+	if options[0]["code"] != "SUBNET" {
+		t.Errorf("options[0][code] = %v, want SUBNET", options[0]["code"])
+	}
+	if options[0]["address"] != "192.0.2.0" {
+		t.Errorf("options[0][address] = %v, want masked 192.0.2.0", options[0]["address"])
+	}
+}
+
+func TestFlattenEDNS0GenericFallback(t *testing.T) {
+	opt := &dns.OPT{}
+	opt.Hdr.Name = "."
+	opt.Option = append(opt.Option, &dns.EDNS0_EXPIRE{Expire: 3600})
+
+	edns := flattenEDNS0(opt, nil, nil)
+	options := edns["options"].([]map[string]interface{})
+	if len(options) != 1 {
+		t.Fatalf("flattenEDNS0 options = %#v, want one fallback option", options)
+	}
+	if _, ok := options[0]["code"].(string); !ok {
+		t.Errorf("options[0][code] = %#v, want a string", options[0]["code"])
+	}
+	if _, ok := options[0]["data_hex"]; ok {
+		t.Errorf("options[0] has data_hex, want description instead: %#v", options[0])
+	}
+	if _, ok := options[0]["description"]; !ok {
+		t.Errorf("options[0] missing description: %#v", options[0])
+	}
+}
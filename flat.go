@@ -57,7 +57,28 @@ type DnstapFlatT struct {
 	CD              bool      `json:"cd"`
 }
 
-func FlatDnstap(dt *dnstap.Dnstap, ipv4Mask net.IPMask, ipv6Mask net.IPMask) (map[string]interface{}, error) {
+// FlatOptions controls the shape of the map produced by FlatDnstap.
+type FlatOptions struct {
+	// IPv4Mask and IPv6Mask anonymize query/response/EDNS0 addresses.
+	IPv4Mask net.IPMask
+	IPv6Mask net.IPMask
+
+	// LegacyLabels selects the original label-count derivation of
+	// tld/2ld/3ld/4ld instead of the Public Suffix List based one.
+	LegacyLabels bool
+
+	// IncludeResourceRecords adds the answers/authorities/additionals
+	// sections to the output. A full resolver response can make these
+	// sizeable, so compact-output callers may want to leave it unset.
+	IncludeResourceRecords bool
+}
+
+// FlatDnstap flattens a dnstap message into a generic map suitable for JSON
+// encoding.
+func FlatDnstap(dt *dnstap.Dnstap, opts FlatOptions) (map[string]interface{}, error) {
+	ipv4Mask := opts.IPv4Mask
+	ipv6Mask := opts.IPv6Mask
+
 	var names = map[int]string{
 		2: "tld",
 		3: "2ld",
@@ -76,17 +97,9 @@ func FlatDnstap(dt *dnstap.Dnstap, ipv4Mask net.IPMask, ipv6Mask net.IPMask) (ma
 
 	data["query_time"] = time.Unix(int64(msg.GetQueryTimeSec()), int64(msg.GetQueryTimeNsec())).Format(time.RFC3339Nano)
 	data["response_time"] = time.Unix(int64(msg.GetResponseTimeSec()), int64(msg.GetResponseTimeNsec())).Format(time.RFC3339Nano)
-	if len(msg.GetQueryAddress()) == 4 {
-		data["query_address"] = net.IP(msg.GetQueryAddress()).Mask(ipv4Mask).String()
-	} else {
-		data["query_address"] = net.IP(msg.GetQueryAddress()).Mask(ipv6Mask).String()
-	}
+	data["query_address"] = maskAddress(msg.GetQueryAddress(), ipv4Mask, ipv6Mask)
 	data["query_port"] = msg.GetQueryPort()
-	if len(msg.GetResponseAddress()) == 4 {
-		data["response_address"] = net.IP(msg.GetResponseAddress()).Mask(ipv4Mask).String()
-	} else {
-		data["response_address"] = net.IP(msg.GetResponseAddress()).Mask(ipv6Mask).String()
-	}
+	data["response_address"] = maskAddress(msg.GetResponseAddress(), ipv4Mask, ipv6Mask)
 
 	data["response_port"] = msg.GetResponsePort()
 	data["response_zone"] = msg.GetQueryZone()
@@ -111,21 +124,66 @@ func FlatDnstap(dt *dnstap.Dnstap, ipv4Mask net.IPMask, ipv6Mask net.IPMask) (ma
 	}
 
 	if len(dnsMsg.Question) > 0 {
-		data["qname"] = dnsMsg.Question[0].Name
+		qname := dnsMsg.Question[0].Name
+		data["qname"] = qname
 		data["qclass"] = dns.ClassToString[dnsMsg.Question[0].Qclass]
 		data["qtype"] = dns.TypeToString[dnsMsg.Question[0].Qtype]
-		labels := strings.Split(dnsMsg.Question[0].Name, ".")
-		labelsLen := len(labels)
-		for i, n := range names {
-			if labelsLen-i >= 0 {
-				data[n] = strings.Join(labels[labelsLen-i:labelsLen-1], ".")
+
+		publicSuffix, registeredDomain, subdomain := DefaultNameDecomposer.Decompose(qname)
+		data["public_suffix"] = publicSuffix
+		data["registered_domain"] = registeredDomain
+		data["subdomain"] = subdomain
+
+		if opts.LegacyLabels {
+			labels := strings.Split(qname, ".")
+			labelsLen := len(labels)
+			for i, n := range names {
+				if labelsLen-i >= 0 {
+					data[n] = strings.Join(labels[labelsLen-i:labelsLen-1], ".")
+				} else {
+					data[n] = qname
+				}
+			}
+		} else {
+			data["tld"] = publicSuffix
+			data["2ld"] = registeredDomain
+			data["3ld"] = eTLDPlusN(registeredDomain, subdomain, 1)
+			data["4ld"] = eTLDPlusN(registeredDomain, subdomain, 2)
+		}
+
+		if unicodeName, err := decodeIDNAQname(qname); err != nil {
+			data["qname_idna_error"] = err.Error()
+		} else if unicodeName != "" {
+			data["qname_unicode"] = unicodeName
+		}
+
+		if ip, ok := ptrTargetAddress(qname); ok {
+			if ip4 := ip.To4(); ip4 != nil {
+				data["qname_ptr_target"] = ip4.Mask(ipv4Mask).String()
 			} else {
-				data[n] = dnsMsg.Question[0].Name
+				data["qname_ptr_target"] = ip.Mask(ipv6Mask).String()
 			}
 		}
+
 		data["message_size"] = len(dnsMessage)
 		data["txid"] = dnsMsg.MsgHdr.Id
 	}
+	if edns := flattenEDNS0(dnsMsg.IsEdns0(), ipv4Mask, ipv6Mask); edns != nil {
+		if ede, ok := edns["ede_code"]; ok {
+			data["ede_code"] = ede
+		}
+		data["edns"] = edns
+	}
+
+	if opts.IncludeResourceRecords {
+		data["answers"] = flattenRRSection(dnsMsg.Answer, ipv4Mask, ipv6Mask)
+		data["authorities"] = flattenRRSection(dnsMsg.Ns, ipv4Mask, ipv6Mask)
+		data["additionals"] = flattenRRSection(dnsMsg.Extra, ipv4Mask, ipv6Mask)
+	}
+	data["answer_count"] = len(dnsMsg.Answer)
+	data["authority_count"] = len(dnsMsg.Ns)
+	data["additional_count"] = len(dnsMsg.Extra)
+
 	data["rcode"] = dns.RcodeToString[dnsMsg.Rcode]
 	data["aa"] = dnsMsg.Authoritative
 	data["tc"] = dnsMsg.Truncated
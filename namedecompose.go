@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// NameDecomposer splits a DNS query name into its Public Suffix List
+// components: the public suffix itself (e.g. "co.uk"), the registered
+// domain (eTLD+1, e.g. "example.co.uk") and the subdomain labels above
+// it (e.g. "www"). For a name with no registrable domain, such as a bare
+// public suffix ("co.uk."), only the public suffix is set; all three are
+// empty for the root zone.
+type NameDecomposer interface {
+	Decompose(qname string) (publicSuffix, registeredDomain, subdomain string)
+}
+
+// PSLDecomposer decomposes names using the Public Suffix List, via
+// golang.org/x/net/publicsuffix.
+type PSLDecomposer struct{}
+
+func (PSLDecomposer) Decompose(qname string) (publicSuffix_, registeredDomain, subdomain string) {
+	name := strings.ToLower(strings.TrimSuffix(qname, "."))
+	if name == "" {
+		return "", "", ""
+	}
+
+	publicSuffix_, _ = publicsuffix.PublicSuffix(name)
+
+	registeredDomain, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return publicSuffix_, "", ""
+	}
+
+	if len(name) > len(registeredDomain)+1 {
+		subdomain = name[:len(name)-len(registeredDomain)-1]
+	}
+
+	return publicSuffix_, registeredDomain, subdomain
+}
+
+// DefaultNameDecomposer is the NameDecomposer used by FlatDnstap.
+var DefaultNameDecomposer NameDecomposer = PSLDecomposer{}
+
+// eTLDPlusN returns the registered domain with n additional labels from
+// subdomain reattached, taking first the labels nearest the registered
+// domain and moving away from the root (e.g. n=1 yields eTLD+2). n<=0 returns registeredDomain unchanged.
+func eTLDPlusN(registeredDomain, subdomain string, n int) string {
+	if n <= 0 || subdomain == "" || registeredDomain == "" {
+		return registeredDomain
+	}
+
+	labels := strings.Split(subdomain, ".")
+	if n > len(labels) {
+		n = len(labels)
+	}
+	extra := labels[len(labels)-n:]
+
+	return strings.Join(extra, ".") + "." + registeredDomain
+}
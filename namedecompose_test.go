@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import "testing"
+
+func TestPSLDecomposerDecompose(t *testing.T) {
+	tests := []struct {
+		qname            string
+		publicSuffix     string
+		registeredDomain string
+		subdomain        string
+	}{
+		{"www.example.com.", "com", "example.com", "www"},
+		{"foo.co.uk.", "co.uk", "foo.co.uk", ""},
+		{"www.bar.foo.co.uk.", "co.uk", "foo.co.uk", "www.bar"},
+		{"FOO.CO.UK.", "co.uk", "foo.co.uk", ""},
+		{"bar.s3.amazonaws.com.", "s3.amazonaws.com", "bar.s3.amazonaws.com", ""},
+		{"com.", "com", "", ""},
+		{"co.uk.", "co.uk", "", ""},
+		{".", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		publicSuffix, registeredDomain, subdomain := DefaultNameDecomposer.Decompose(tt.qname)
+		if publicSuffix != tt.publicSuffix || registeredDomain != tt.registeredDomain || subdomain != tt.subdomain {
+			t.Errorf("Decompose(%q) = (%q, %q, %q), want (%q, %q, %q)",
+				tt.qname, publicSuffix, registeredDomain, subdomain,
+				tt.publicSuffix, tt.registeredDomain, tt.subdomain)
+		}
+	}
+}
+
+func TestETLDPlusN(t *testing.T) {
+	tests := []struct {
+		registeredDomain string
+		subdomain        string
+		n                int
+		want             string
+	}{
+		{"example.com", "www.bar", 1, "bar.example.com"},
+		{"example.com", "www.bar", 2, "www.bar.example.com"},
+		{"example.com", "www.bar", 5, "www.bar.example.com"},
+		{"example.com", "www.bar", 0, "example.com"},
+		{"example.com", "", 1, "example.com"},
+		{"", "www", 1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := eTLDPlusN(tt.registeredDomain, tt.subdomain, tt.n); got != tt.want {
+			t.Errorf("eTLDPlusN(%q, %q, %d) = %q, want %q", tt.registeredDomain, tt.subdomain, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// decodeIDNAQname decodes a qname's xn-- A-labels into their Unicode
+// U-label form. It returns ("", nil) if qname has no A-label to decode.
+func decodeIDNAQname(qname string) (string, error) {
+	if !strings.Contains(qname, "xn--") {
+		return "", nil
+	}
+
+	trailingDot := strings.HasSuffix(qname, ".")
+	name := strings.TrimSuffix(qname, ".")
+
+	unicodeName, err := idna.Lookup.ToUnicode(name)
+	if err != nil {
+		return "", err
+	}
+	if trailingDot {
+		unicodeName += "."
+	}
+	return unicodeName, nil
+}
+
+// ptrTargetAddress reverse-parses a PTR qname (*.in-addr.arpa. or
+// *.ip6.arpa.) back into the address it names. ok is false if qname is
+// not a reverse-lookup name, or is malformed.
+func ptrTargetAddress(qname string) (ip net.IP, ok bool) {
+	name := strings.ToLower(strings.TrimSuffix(qname, "."))
+
+	switch {
+	case strings.HasSuffix(name, ".in-addr.arpa"):
+		return reverseIPv4PTR(strings.TrimSuffix(name, ".in-addr.arpa"))
+	case strings.HasSuffix(name, ".ip6.arpa"):
+		return reverseIPv6PTR(strings.TrimSuffix(name, ".ip6.arpa"))
+	}
+
+	return nil, false
+}
+
+func reverseIPv4PTR(labels string) (net.IP, bool) {
+	octets := strings.Split(labels, ".")
+	if len(octets) != 4 {
+		return nil, false
+	}
+
+	reversed := make([]string, 4)
+	for i, o := range octets {
+		n, err := strconv.Atoi(o)
+		if err != nil || n < 0 || n > 255 {
+			return nil, false
+		}
+		reversed[3-i] = o
+	}
+
+	ip := net.ParseIP(strings.Join(reversed, ".")).To4()
+	if ip == nil {
+		return nil, false
+	}
+	return ip, true
+}
+
+func reverseIPv6PTR(labels string) (net.IP, bool) {
+	nibbleLabels := strings.Split(labels, ".")
+	if len(nibbleLabels) != 32 {
+		return nil, false
+	}
+
+	var nibbles [32]byte
+	for i, l := range nibbleLabels {
+		if len(l) != 1 {
+			return nil, false
+		}
+		n, err := strconv.ParseUint(l, 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		nibbles[31-i] = byte(n)
+	}
+
+	raw := make([]byte, 16)
+	for i := 0; i < 16; i++ {
+		raw[i] = nibbles[2*i]<<4 | nibbles[2*i+1]
+	}
+	return net.IP(raw), true
+}
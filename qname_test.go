@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/idna"
+)
+
+// ip6ArpaName builds the nibble-reversed *.ip6.arpa. PTR name for ip,
+// the inverse of reverseIPv6PTR.
+func ip6ArpaName(ip net.IP) string {
+	ip16 := ip.To16()
+	nibbles := make([]string, 0, 32)
+	for i := len(ip16) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, fmt.Sprintf("%x", ip16[i]&0x0f), fmt.Sprintf("%x", ip16[i]>>4))
+	}
+	return strings.Join(nibbles, ".") + ".ip6.arpa."
+}
+
+func TestDecodeIDNAQname(t *testing.T) {
+	if got, err := decodeIDNAQname("example.com."); err != nil || got != "" {
+		t.Errorf("decodeIDNAQname(example.com.) = (%q, %v), want (\"\", nil)", got, err)
+	}
+
+	unicodeName := "täst.example"
+	aLabel, err := idna.Lookup.ToASCII(unicodeName)
+	if err != nil {
+		t.Fatalf("idna.ToASCII(%q): %v", unicodeName, err)
+	}
+
+	got, err := decodeIDNAQname(aLabel + ".")
+	if err != nil {
+		t.Fatalf("decodeIDNAQname(%q): %v", aLabel, err)
+	}
+	if want := unicodeName + "."; got != want {
+		t.Errorf("decodeIDNAQname(%q) = %q, want %q", aLabel, got, want)
+	}
+
+	if _, err := decodeIDNAQname("xn--a."); err == nil {
+		t.Errorf("decodeIDNAQname(xn--a.) error = nil, want a decode error for invalid punycode")
+	}
+}
+
+func TestPtrTargetAddress(t *testing.T) {
+	v6 := net.ParseIP("2001:db8::1")
+	tests := []struct {
+		qname string
+		want  string
+		ok    bool
+	}{
+		{"55.2.0.192.in-addr.arpa.", "192.0.2.55", true},
+		{"not-a-ptr.example.com.", "", false},
+		{"1.2.3.in-addr.arpa.", "", false},
+		{"256.2.0.192.in-addr.arpa.", "", false},
+		{ip6ArpaName(v6), v6.String(), true},
+	}
+
+	for _, tt := range tests {
+		ip, ok := ptrTargetAddress(tt.qname)
+		if ok != tt.ok {
+			t.Errorf("ptrTargetAddress(%q) ok = %v, want %v", tt.qname, ok, tt.ok)
+			continue
+		}
+		if ok && ip.String() != tt.want {
+			t.Errorf("ptrTargetAddress(%q) = %q, want %q", tt.qname, ip.String(), tt.want)
+		}
+	}
+}
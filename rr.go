@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// flattenRRSection converts a slice of RRs (an answer, authority or
+// additional section) into dtap's generic record representation, one
+// map per RR: {name, type, class, ttl, rdata}.
+func flattenRRSection(rrs []dns.RR, ipv4Mask net.IPMask, ipv6Mask net.IPMask) []map[string]interface{} {
+	section := make([]map[string]interface{}, 0, len(rrs))
+	for _, rr := range rrs {
+		hdr := rr.Header()
+		section = append(section, map[string]interface{}{
+			"name":  hdr.Name,
+			"type":  dns.TypeToString[hdr.Rrtype],
+			"class": dns.ClassToString[hdr.Class],
+			"ttl":   hdr.Ttl,
+			"rdata": flattenRdata(rr, ipv4Mask, ipv6Mask),
+		})
+	}
+	return section
+}
+
+// flattenRdata returns a type-aware structured value for rr's data,
+// falling back to the RR's zone-file string form for unhandled types.
+func flattenRdata(rr dns.RR, ipv4Mask net.IPMask, ipv6Mask net.IPMask) interface{} {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A.Mask(ipv4Mask).String()
+	case *dns.AAAA:
+		return v.AAAA.Mask(ipv6Mask).String()
+	case *dns.CNAME:
+		return v.Target
+	case *dns.NS:
+		return v.Ns
+	case *dns.PTR:
+		return v.Ptr
+	case *dns.MX:
+		return map[string]interface{}{
+			"preference": v.Preference,
+			"exchange":   v.Mx,
+		}
+	case *dns.SOA:
+		return map[string]interface{}{
+			"ns":      v.Ns,
+			"mbox":    v.Mbox,
+			"serial":  v.Serial,
+			"refresh": v.Refresh,
+			"retry":   v.Retry,
+			"expire":  v.Expire,
+			"minttl":  v.Minttl,
+		}
+	case *dns.TXT:
+		return map[string]interface{}{
+			"text":   strings.Join(v.Txt, ""),
+			"chunks": v.Txt,
+		}
+	case *dns.SRV:
+		return map[string]interface{}{
+			"priority": v.Priority,
+			"weight":   v.Weight,
+			"port":     v.Port,
+			"target":   v.Target,
+		}
+	case *dns.SVCB:
+		return flattenSVCBParams(v.Value)
+	case *dns.HTTPS:
+		return flattenSVCBParams(v.Value)
+	default:
+		return map[string]interface{}{
+			"rr_string": rr.String(),
+		}
+	}
+}
+
+// flattenSVCBParams flattens SVCB/HTTPS key-value parameters into a map
+// keyed by parameter name (e.g. "alpn", "port"), values as their string
+// presentation form.
+func flattenSVCBParams(values []dns.SVCBKeyValue) map[string]interface{} {
+	params := make(map[string]interface{}, len(values))
+	for _, kv := range values {
+		params[kv.Key().String()] = kv.String()
+	}
+	return params
+}
+
+// flattenRdataString renders rr's data as a single zone-file presentation
+// string (rr.String() with the header stripped), for schemas such as ECS's
+// dns.answers.data that declare rdata as a plain keyword rather than a
+// structured value. A/AAAA addresses are masked first.
+func flattenRdataString(rr dns.RR, ipv4Mask net.IPMask, ipv6Mask net.IPMask) string {
+	switch v := rr.(type) {
+	case *dns.A:
+		masked := *v
+		masked.A = v.A.Mask(ipv4Mask)
+		return rdataString(&masked)
+	case *dns.AAAA:
+		masked := *v
+		masked.AAAA = v.AAAA.Mask(ipv6Mask)
+		return rdataString(&masked)
+	default:
+		return rdataString(rr)
+	}
+}
+
+// rdataString strips rr's header from its zone-file presentation form,
+// leaving just the rdata fields.
+func rdataString(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func mustParseRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatalf("dns.NewRR(%q): %v", s, err)
+	}
+	return rr
+}
+
+func TestFlattenRdata(t *testing.T) {
+	ipv4Mask := net.CIDRMask(24, 32)
+	ipv6Mask := net.CIDRMask(64, 128)
+
+	a := flattenRdata(mustParseRR(t, "example.com. 300 IN A 192.0.2.55"), ipv4Mask, ipv6Mask)
+	if a != "192.0.2.0" {
+		t.Errorf("flattenRdata(A) = %v, want masked 192.0.2.0", a)
+	}
+
+	cname := flattenRdata(mustParseRR(t, "example.com. 300 IN CNAME target.example.com."), ipv4Mask, ipv6Mask)
+	if cname != "target.example.com." {
+		t.Errorf("flattenRdata(CNAME) = %v, want target.example.com.", cname)
+	}
+
+	mx, ok := flattenRdata(mustParseRR(t, "example.com. 300 IN MX 10 mail.example.com."), ipv4Mask, ipv6Mask).(map[string]interface{})
+	if !ok || mx["preference"] != uint16(10) || mx["exchange"] != "mail.example.com." {
+		t.Errorf("flattenRdata(MX) = %#v, want {preference:10, exchange:mail.example.com.}", mx)
+	}
+
+	unknown, ok := flattenRdata(mustParseRR(t, "example.com. 300 IN CAA 0 issue \"letsencrypt.org\""), ipv4Mask, ipv6Mask).(map[string]interface{})
+	if !ok {
+		t.Fatalf("flattenRdata(CAA) = %#v, want a fallback map", unknown)
+	}
+	if _, ok := unknown["rr_string"]; !ok {
+		t.Errorf("flattenRdata(CAA) missing rr_string fallback: %#v", unknown)
+	}
+}
+
+func TestFlattenRdataString(t *testing.T) {
+	ipv4Mask := net.CIDRMask(24, 32)
+	ipv6Mask := net.CIDRMask(64, 128)
+
+	if got := flattenRdataString(mustParseRR(t, "example.com. 300 IN A 192.0.2.55"), ipv4Mask, ipv6Mask); got != "192.0.2.0" {
+		t.Errorf("flattenRdataString(A) = %q, want 192.0.2.0", got)
+	}
+
+	if got := flattenRdataString(mustParseRR(t, "example.com. 300 IN MX 10 mail.example.com."), ipv4Mask, ipv6Mask); got != "10 mail.example.com." {
+		t.Errorf("flattenRdataString(MX) = %q, want %q", got, "10 mail.example.com.")
+	}
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) 2019 Manabu Sonoda
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dtap
+
+import "net"
+
+// maskAddress anonymizes a raw dnstap address (4 bytes for IPv4, 16 for
+// IPv6) with the appropriate mask and returns its string form.
+func maskAddress(addr []byte, ipv4Mask net.IPMask, ipv6Mask net.IPMask) string {
+	if len(addr) == 4 {
+		return net.IP(addr).Mask(ipv4Mask).String()
+	}
+	return net.IP(addr).Mask(ipv6Mask).String()
+}